@@ -0,0 +1,41 @@
+// Package config defines the application's runtime configuration
+package config
+
+const (
+	// EnvLocal is the App.Environment value used during local development. Renderers and other services key
+	// hot-reload/debug behavior off this constant rather than a boolean, so additional environments (staging,
+	// etc.) can be added without touching that logic.
+	EnvLocal = "local"
+
+	// TemplateDir is the path, relative to the repository root, of the directory containing the application's
+	// template files
+	TemplateDir = "templates"
+
+	// TemplateExt is the file extension used for template files, appended to template names when parsing and
+	// executing them
+	TemplateExt = ".gohtml"
+)
+
+// Config stores complete application configuration
+type Config struct {
+	App       AppConfig
+	Templates TemplatesConfig
+}
+
+// AppConfig stores application-level configuration
+type AppConfig struct {
+	// Environment is the current application environment, such as config.EnvLocal
+	Environment string
+}
+
+// TemplatesConfig stores configuration for the template renderer
+type TemplatesConfig struct {
+	// Engine selects the rendering engine, matching one of the services.Engine constants (for example
+	// "html" or "safehtml"). Defaults to services.EngineHTML when empty.
+	Engine string
+
+	// MaxCacheSize is the maximum number of parsed templates the renderer retains before evicting the least
+	// recently used entry. A value of 0 means the cache is unbounded. Only the "html" engine applies it; the
+	// "safehtml" engine's cache is always unbounded.
+	MaxCacheSize int
+}
@@ -0,0 +1,38 @@
+// Package funcmap provides the template function maps available to templates. Each supported rendering
+// engine gets its own adapter, since engines don't necessarily agree on function signatures.
+package funcmap
+
+import (
+	"html/template"
+	"strings"
+
+	safehtml "github.com/google/safehtml/template"
+)
+
+// funcs lists the functions shared by every engine's function map
+var funcs = map[string]any{
+	"ToUpper": strings.ToUpper,
+	"ToLower": strings.ToLower,
+}
+
+// GetFuncMap returns the function map used by the html/template engine
+func GetFuncMap() template.FuncMap {
+	fm := make(template.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		fm[name] = fn
+	}
+
+	return fm
+}
+
+// GetSafeHTMLFuncMap returns the function map used by the safehtml/template engine. safehtml.FuncMap is a
+// distinct type from html/template.FuncMap, so it can't simply reuse GetFuncMap's map even though the
+// underlying functions are the same.
+func GetSafeHTMLFuncMap() safehtml.FuncMap {
+	fm := make(safehtml.FuncMap, len(funcs))
+	for name, fn := range funcs {
+		fm[name] = fn
+	}
+
+	return fm
+}
@@ -2,136 +2,634 @@ package services
 
 import (
 	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"io/fs"
+	"os"
 	"path"
 	"path/filepath"
 	"runtime"
 	"sync"
+	"time"
 
 	"goweb/config"
 	"goweb/funcmap"
+	"goweb/templates"
 )
 
-// TemplateRenderer provides a flexible and easy to use method of rendering simple templates or complex sets of
+// Engine identifies a supported template backend, selected via config.Templates.Engine
+type Engine string
+
+const (
+	// EngineHTML parses and executes templates with the standard library's html/template package. This is
+	// the default engine.
+	EngineHTML Engine = "html"
+
+	// EngineSafeHTML parses and executes templates with github.com/google/safehtml/template, which enforces
+	// contextual auto-escaping with trusted-source constraints on top of what html/template provides
+	EngineSafeHTML Engine = "safehtml"
+)
+
+// Renderer is implemented by each supported template engine, so callers can register and render named
+// template sets — along with the streaming/cache-management APIs — without depending on which concrete
+// engine backs them. NewTemplateRenderer's caller-facing return type, so every engine must implement it in
+// full; choosing a different Engine must never silently drop functionality.
+//
+// Callers register every template set up front with RegisterSet and render it by name with Render, instead
+// of threading a group/id/name/files/directories tuple through a request handler on every call. Parse and
+// Execute, the ad-hoc per-request API RegisterSet/Render replace, are intentionally not part of this
+// interface; they remain on the concrete engine types. Parse is the primitive ParseRegisteredSets and Render
+// use to compile a set; Render executes the cached set itself and does not call Execute.
+type Renderer interface {
+	// ExecuteStream renders the group/id template directly to w, without allocating an intermediate buffer,
+	// and returns the number of bytes written
+	ExecuteStream(group, id, name string, data interface{}, w io.Writer) (int64, error)
+
+	// Load returns the template previously compiled for group/id by RegisterSet/ParseRegisteredSets. Its
+	// concrete type depends on the engine (for example *html/template.Template for EngineHTML).
+	Load(group, id string) (any, error)
+
+	// HasChanged reports whether the resolved set of files/directories differs from what is currently cached
+	// for group/id
+	HasChanged(group, id string, files []string, directories []string) (bool, error)
+
+	// RegisterSet registers a named template set described by spec. Call ParseRegisteredSets to parse every
+	// registered set up front.
+	RegisterSet(name string, spec TemplateSpec)
+
+	// ParseRegisteredSets eagerly parses every template set registered via RegisterSet
+	ParseRegisteredSets() error
+
+	// Render executes the named template set registered via RegisterSet, writing the result directly to w
+	Render(w io.Writer, setName string, data interface{}) error
+
+	// CacheStats returns a snapshot of the cache's current size and cumulative hit/miss/eviction counts
+	CacheStats() CacheStats
+
+	// Invalidate removes a single group/id from the cache, forcing it to be reparsed next time it is
+	// requested
+	Invalidate(group, id string)
+
+	// InvalidateAll clears the entire cache, forcing everything to be reparsed next time it is requested
+	InvalidateAll()
+
+	// GetFS returns the file system that templates are parsed from
+	GetFS() fs.FS
+}
+
+// NewTemplateRenderer creates a Renderer backed by the engine selected in cfg.Templates.Engine, defaulting to
+// EngineHTML when unset
+func NewTemplateRenderer(cfg *config.Config) Renderer {
+	switch Engine(cfg.Templates.Engine) {
+	case EngineSafeHTML:
+		return NewSafeHTMLRenderer(cfg)
+	default:
+		return NewHTMLRenderer(cfg)
+	}
+}
+
+// bufferPool recycles the *bytes.Buffer instances Execute renders into, so hot paths rendering many small
+// htmx partials don't allocate a fresh buffer on every call
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// ReleaseBuffer returns buf to the shared buffer pool. Callers must call this once they're done with a
+// RenderResult's Buffer, for example after writing it to an http.ResponseWriter.
+func ReleaseBuffer(buf *bytes.Buffer) {
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// RenderResult is returned by Execute. It carries the rendered output alongside metadata that lets callers
+// serve conditional GETs without retransmitting unchanged content.
+type RenderResult struct {
+	// Buffer holds the rendered template output. It comes from the shared bufferPool, so callers must pass
+	// it to ReleaseBuffer once they're done with it.
+	Buffer *bytes.Buffer
+
+	// ETag is a content hash of the rendered output, suitable for use as an HTTP ETag header
+	ETag string
+
+	// LastModified is when the underlying template was last (re)parsed, suitable for use as an HTTP
+	// Last-Modified header
+	LastModified time.Time
+}
+
+// cacheEntry stores a parsed template alongside a hash of the file set it was parsed from, so the renderer
+// can detect when the underlying files change without having to reparse them first
+type cacheEntry struct {
+	// template stores the parsed template
+	template *template.Template
+
+	// hash stores a content hash (or, in config.EnvLocal, a modification-time hash) of the files the
+	// template was parsed from
+	hash string
+
+	// insertedAt is when this entry was parsed and cached, used as the RenderResult.LastModified value
+	insertedAt time.Time
+}
+
+// cacheRecord is the value stored in each cacheOrder element so the owning key is available during eviction
+type cacheRecord struct {
+	key   string
+	entry cacheEntry
+}
+
+// templateSetGroup is the cache group under which named template sets registered via RegisterSet are stored,
+// so sets share the same hashing/eviction machinery as Parse/Execute
+const templateSetGroup = "set"
+
+// TemplateSpec describes the files that make up a named, pre-parsable template set: a base layout, a
+// page-specific template, and any component directories to parse alongside them
+type TemplateSpec struct {
+	// Layout is the path (without extension) to the base layout file, or empty if the set has no layout
+	Layout string
+
+	// Page is the path (without extension) to the page-specific template file, or empty if the set has no
+	// page-specific file
+	Page string
+
+	// Components lists directories of reusable component templates to parse alongside Layout and Page
+	Components []string
+
+	// Name is the defined template to execute within the parsed set. Defaults to "main" if empty, which is
+	// the block the bundled layout defines.
+	Name string
+}
+
+// execName returns the defined template name to execute for this spec, defaulting to "main"
+func (s TemplateSpec) execName() string {
+	if s.Name == "" {
+		return "main"
+	}
+	return s.Name
+}
+
+// CacheStats summarizes the current state of the template cache
+type CacheStats struct {
+	// Size is the number of templates currently cached
+	Size int
+
+	// MaxSize is the configured eviction threshold, or 0 if the cache is unbounded
+	MaxSize int
+
+	// Hits is the number of cache lookups that found a cached template
+	Hits uint64
+
+	// Misses is the number of cache lookups that found nothing cached
+	Misses uint64
+
+	// Evictions is the number of entries removed to stay within MaxSize
+	Evictions uint64
+}
+
+// HTMLRenderer provides a flexible and easy to use method of rendering simple templates or complex sets of
 // templates while also providing caching and/or hot-reloading depending on your current environment
-type TemplateRenderer struct {
-	// templateCache stores a cache of parsed page templates
-	templateCache sync.Map
+type HTMLRenderer struct {
+	// cacheMu guards cache, cacheOrder and cacheStats
+	cacheMu sync.Mutex
+
+	// cache stores a cache of parsed page templates, keyed by group/id
+	cache map[string]*list.Element
+
+	// cacheOrder tracks cache keys in least-recently-used order, with the front being most recently used
+	cacheOrder *list.List
+
+	// cacheStats accumulates hit/miss/eviction counters for CacheStats()
+	cacheStats CacheStats
+
+	// maxCacheSize is the maximum number of parsed templates to retain before evicting the least recently
+	// used entry. A value of 0 means the cache is unbounded
+	maxCacheSize int
 
 	// funcMap stores the template function map
 	funcMap template.FuncMap
 
-	// templatePath stores the complete path to the templates directory
-	templatesPath string
+	// fsys stores the file system that templates are parsed from. In config.EnvLocal this is a disk-backed
+	// fs.FS rooted at the templates directory so changes are reflected without rebuilding the binary. In all
+	// other environments it is the embedded templates.FS, so the binary has no dependency on the working
+	// directory or the presence of the templates directory on disk.
+	fsys fs.FS
+
+	// local indicates the app is running in config.EnvLocal, so file changes are detected by modification
+	// time rather than by hashing file contents on every request
+	local bool
+
+	// setsMu guards sets. It is taken by RegisterSet, ParseRegisteredSets and parseSet, and briefly by every
+	// Render call (in every environment) to look up the set's spec; Render reads the parsed template itself
+	// via the regular cache, which has its own locking.
+	setsMu sync.Mutex
+
+	// sets stores every TemplateSpec registered via RegisterSet, keyed by name, in registration order
+	sets     map[string]TemplateSpec
+	setOrder []string
 
 	// config stores application configuration
 	config *config.Config
 }
 
-// NewTemplateRenderer creates a new TemplateRenderer
-func NewTemplateRenderer(cfg *config.Config) *TemplateRenderer {
-	t := &TemplateRenderer{
-		templateCache: sync.Map{},
-		funcMap:       funcmap.GetFuncMap(),
-		config:        cfg,
+// NewHTMLRenderer creates a new HTMLRenderer
+func NewHTMLRenderer(cfg *config.Config) *HTMLRenderer {
+	t := &HTMLRenderer{
+		cache:        make(map[string]*list.Element),
+		cacheOrder:   list.New(),
+		maxCacheSize: cfg.Templates.MaxCacheSize,
+		funcMap:      funcmap.GetFuncMap(),
+		config:       cfg,
+		fsys:         templates.FS,
+		sets:         make(map[string]TemplateSpec),
 	}
 
-	// Gets the complete templates directory path
-	// This is needed incase this is called from a package outside of main, such as within tests
-	_, b, _, _ := runtime.Caller(0)
-	d := path.Join(path.Dir(b))
-	t.templatesPath = filepath.Join(filepath.Dir(d), config.TemplateDir)
+	if cfg.App.Environment == config.EnvLocal {
+		// Gets the complete templates directory path so it can be read directly off disk
+		// This is needed incase this is called from a package outside of main, such as within tests
+		_, b, _, _ := runtime.Caller(0)
+		d := path.Join(path.Dir(b))
+		t.fsys = os.DirFS(filepath.Join(filepath.Dir(d), config.TemplateDir))
+		t.local = true
+	}
 
 	return t
 }
 
-func (t *TemplateRenderer) ParseAndExecute(group, id, name string, files []string, directories []string, data interface{}) (*bytes.Buffer, error) {
-	var buf *bytes.Buffer
-	var err error
+// Parse compiles the named template from the given files and/or directories, caching the result under
+// group/id.
+//
+// Deprecated: register a TemplateSpec with RegisterSet and render it by name with Render instead of calling
+// Parse directly per request. Parse is no longer part of Renderer; it remains exported on HTMLRenderer
+// because parseSet still calls it to compile a registered set.
+func (t *HTMLRenderer) Parse(group, id, name string, files []string, directories []string) error {
+	cacheKey := t.getCacheKey(group, id)
 
-	if err = t.Parse(group, id, name, files, directories); err != nil {
-		return nil, err
+	entry, cached := t.peekEntry(cacheKey)
+
+	// Outside of local mode, once a template is parsed it never needs to be parsed again
+	if cached && t.config.App.Environment != config.EnvLocal {
+		t.recordHit()
+		return nil
 	}
-	if buf, err = t.Execute(group, id, name, data); err != nil {
-		return nil, err
+
+	paths, err := t.resolvePaths(files, directories)
+	if err != nil {
+		return err
+	}
+
+	hash, err := t.computeHash(paths)
+	if err != nil {
+		return err
 	}
 
-	return buf, nil
+	// In local mode, only reparse if the resolved files actually changed since they were last cached
+	if cached && entry.hash == hash {
+		t.recordHit()
+		return nil
+	}
+
+	t.recordMiss()
+
+	// Initialize the parsed template with the function map
+	parsed := template.New(name + config.TemplateExt).
+		Funcs(t.funcMap)
+
+	if len(paths) > 0 {
+		parsed, err = parsed.ParseFS(t.fsys, paths...)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Store the template so this process only happens once the file set changes
+	t.storeEntry(cacheKey, cacheEntry{template: parsed, hash: hash, insertedAt: time.Now()})
+
+	return nil
 }
 
-func (t *TemplateRenderer) Parse(group, id, name string, files []string, directories []string) error {
-	cacheKey := t.getCacheKey(group, id)
+// HasChanged reports whether the resolved set of files/directories differs from what is currently cached for
+// the given group/id. In config.EnvLocal this compares file modification times; otherwise it compares content
+// hashes. A group/id with nothing cached yet is always reported as changed.
+func (t *HTMLRenderer) HasChanged(group, id string, files []string, directories []string) (bool, error) {
+	entry, cached := t.peekEntry(t.getCacheKey(group, id))
+	if !cached {
+		return true, nil
+	}
 
-	// Check if the template has not yet been parsed or if the app environment is local, so that
-	// templates reflect changes without having the restart the server
-	if _, err := t.Load(group, id); err != nil || t.config.App.Environment == config.EnvLocal {
-		// Initialize the parsed template with the function map
-		parsed := template.New(name + config.TemplateExt).
-			Funcs(t.funcMap)
-
-		// Parse all files provided
-		if len(files) > 0 {
-			for k, v := range files {
-				files[k] = fmt.Sprintf("%s/%s%s", t.templatesPath, v, config.TemplateExt)
-			}
+	paths, err := t.resolvePaths(files, directories)
+	if err != nil {
+		return false, err
+	}
 
-			parsed, err = parsed.ParseFiles(files...)
-			if err != nil {
-				return err
-			}
+	hash, err := t.computeHash(paths)
+	if err != nil {
+		return false, err
+	}
+
+	return hash != entry.hash, nil
+}
+
+// resolvePaths expands files and directories into the full set of virtual paths within t.fsys that make up a
+// template's file set
+func (t *HTMLRenderer) resolvePaths(files []string, directories []string) ([]string, error) {
+	paths := make([]string, 0, len(files))
+
+	for _, f := range files {
+		paths = append(paths, f+config.TemplateExt)
+	}
+
+	for _, dir := range directories {
+		matches, err := fs.Glob(t.fsys, fmt.Sprintf("%s/*%s", dir, config.TemplateExt))
+		if err != nil {
+			return nil, err
 		}
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
 
-		// Parse all templates within the provided directories
-		for _, dir := range directories {
-			dir = fmt.Sprintf("%s/%s/*%s", t.templatesPath, dir, config.TemplateExt)
-			parsed, err = parsed.ParseGlob(dir)
+// computeHash returns a stable hash representing the contents of paths. In local mode, file modification
+// times and sizes are hashed instead of contents so reparse checks stay cheap during development.
+func (t *HTMLRenderer) computeHash(paths []string) (string, error) {
+	h := sha256.New()
+
+	for _, p := range paths {
+		fmt.Fprintf(h, "%s\x00", p)
+
+		if t.local {
+			info, err := fs.Stat(t.fsys, p)
 			if err != nil {
-				return err
+				return "", err
 			}
+			fmt.Fprintf(h, "%d:%d\x00", info.Size(), info.ModTime().UnixNano())
+			continue
 		}
 
-		// Store the template so this process only happens once
-		t.templateCache.Store(cacheKey, parsed)
+		b, err := fs.ReadFile(t.fsys, p)
+		if err != nil {
+			return "", err
+		}
+		h.Write(b)
 	}
 
-	return nil
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// RegisterSet registers a named template set described by spec. Registration only records the spec; call
+// ParseRegisteredSets (typically once at application startup) to parse every registered set up front. In
+// config.EnvLocal, Render reparses a set's spec on demand instead, so changes don't require a restart.
+func (t *HTMLRenderer) RegisterSet(name string, spec TemplateSpec) {
+	t.setsMu.Lock()
+	defer t.setsMu.Unlock()
+
+	if _, exists := t.sets[name]; !exists {
+		t.setOrder = append(t.setOrder, name)
+	}
+	t.sets[name] = spec
 }
 
-func (t *TemplateRenderer) Execute(group, id, name string, data interface{}) (*bytes.Buffer, error) {
-	tmpl, err := t.Load(group, id)
+// ParseRegisteredSets eagerly parses every template set registered via RegisterSet, returning a single error
+// that joins the failures of every set that didn't parse. Call this once at application startup so malformed
+// templates are caught before the first request rather than on first render.
+func (t *HTMLRenderer) ParseRegisteredSets() error {
+	t.setsMu.Lock()
+	names := append([]string(nil), t.setOrder...)
+	t.setsMu.Unlock()
+
+	var errs []error
+	for _, name := range names {
+		if err := t.parseSet(name); err != nil {
+			errs = append(errs, fmt.Errorf("template set %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Render executes the named template set registered via RegisterSet, writing the result directly to w. In
+// production the set must already have been parsed by ParseRegisteredSets; in config.EnvLocal it is reparsed
+// first if its files have changed.
+func (t *HTMLRenderer) Render(w io.Writer, setName string, data interface{}) error {
+	t.setsMu.Lock()
+	spec, ok := t.sets[setName]
+	t.setsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("template set %q is not registered", setName)
+	}
+
+	if t.config.App.Environment == config.EnvLocal {
+		if err := t.parseSet(setName); err != nil {
+			return err
+		}
+	}
+
+	tmpl, err := t.loadTemplate(templateSetGroup, setName)
 	if err != nil {
+		return err
+	}
+
+	return tmpl.ExecuteTemplate(w, spec.execName()+config.TemplateExt, data)
+}
+
+// parseSet parses a single registered template set by name
+func (t *HTMLRenderer) parseSet(name string) error {
+	t.setsMu.Lock()
+	spec, ok := t.sets[name]
+	t.setsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("template set %q is not registered", name)
+	}
+
+	files := make([]string, 0, 2)
+	if spec.Layout != "" {
+		files = append(files, spec.Layout)
+	}
+	if spec.Page != "" {
+		files = append(files, spec.Page)
+	}
+
+	return t.Parse(templateSetGroup, name, spec.execName(), files, spec.Components)
+}
+
+// Execute renders the group/id template into a pooled buffer and returns it alongside cache metadata for
+// conditional GETs. The caller must call ReleaseBuffer(result.Buffer) once it's done with the output.
+//
+// Deprecated: see Parse. Execute is no longer part of Renderer.
+func (t *HTMLRenderer) Execute(group, id, name string, data interface{}) (*RenderResult, error) {
+	entry, ok := t.peekEntry(t.getCacheKey(group, id))
+	if !ok {
+		return nil, errors.New("uncached page template requested")
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	if err := entry.template.ExecuteTemplate(buf, name+config.TemplateExt, data); err != nil {
+		ReleaseBuffer(buf)
 		return nil, err
 	}
 
-	buf := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(buf, name+config.TemplateExt, data)
+	return &RenderResult{
+		Buffer:       buf,
+		ETag:         contentETag(buf.Bytes()),
+		LastModified: entry.insertedAt,
+	}, nil
+}
+
+// ExecuteStream renders the group/id template directly to w, without allocating an intermediate buffer, and
+// returns the number of bytes written. It's meant for hot paths, such as htmx partials, that have nothing
+// further to do with the rendered output besides writing it to the response.
+func (t *HTMLRenderer) ExecuteStream(group, id, name string, data interface{}, w io.Writer) (int64, error) {
+	tmpl, err := t.loadTemplate(group, id)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	return buf, nil
+	cw := &countingWriter{w: w}
+	err = tmpl.ExecuteTemplate(cw, name+config.TemplateExt, data)
+
+	return cw.n, err
+}
+
+// Load returns the *template.Template cached for group/id, boxed as any to satisfy Renderer. Callers within
+// this package that need the concrete type should use loadTemplate instead.
+func (t *HTMLRenderer) Load(group, id string) (any, error) {
+	return t.loadTemplate(group, id)
 }
 
-func (t *TemplateRenderer) Load(group, id string) (*template.Template, error) {
-	load, ok := t.templateCache.Load(t.getCacheKey(group, id))
+func (t *HTMLRenderer) loadTemplate(group, id string) (*template.Template, error) {
+	entry, ok := t.peekEntry(t.getCacheKey(group, id))
 	if !ok {
 		return nil, errors.New("uncached page template requested")
 	}
 
-	tmpl, ok := load.(*template.Template)
+	return entry.template, nil
+}
+
+// contentETag returns a strong ETag value for b's contents
+func contentETag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:]))
+}
+
+// countingWriter wraps an io.Writer to track the total number of bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// CacheStats returns a snapshot of the template cache's current size and cumulative hit/miss/eviction counts
+func (t *HTMLRenderer) CacheStats() CacheStats {
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+
+	stats := t.cacheStats
+	stats.Size = t.cacheOrder.Len()
+	stats.MaxSize = t.maxCacheSize
+
+	return stats
+}
+
+// Invalidate removes a single group/id from the template cache, forcing it to be reparsed next time it is
+// requested
+func (t *HTMLRenderer) Invalidate(group, id string) {
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+
+	key := t.getCacheKey(group, id)
+	if elem, ok := t.cache[key]; ok {
+		delete(t.cache, key)
+		t.cacheOrder.Remove(elem)
+	}
+}
+
+// InvalidateAll clears the entire template cache, forcing every template to be reparsed next time it is
+// requested
+func (t *HTMLRenderer) InvalidateAll() {
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+
+	t.cache = make(map[string]*list.Element)
+	t.cacheOrder = list.New()
+}
+
+// peekEntry fetches a cacheEntry by key, marking it as the most recently used entry on a hit. It does not
+// affect cacheStats.Hits/Misses: those are recorded once per logical Parse call by recordHit/recordMiss, not
+// once per internal lookup, so CacheStats() reflects served-request cache efficiency rather than the number
+// of times the cache happens to be consulted while serving one request.
+func (t *HTMLRenderer) peekEntry(key string) (cacheEntry, bool) {
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+
+	elem, ok := t.cache[key]
 	if !ok {
-		return nil, errors.New("unable to cast cached template")
+		return cacheEntry{}, false
 	}
 
-	return tmpl, nil
+	t.cacheOrder.MoveToFront(elem)
+
+	return elem.Value.(cacheRecord).entry, true
+}
+
+// recordHit and recordMiss update cacheStats. They're called once per logical Parse call: recordHit when the
+// cached entry could be reused as-is, recordMiss when a (re)parse was required.
+func (t *HTMLRenderer) recordHit() {
+	t.cacheMu.Lock()
+	t.cacheStats.Hits++
+	t.cacheMu.Unlock()
+}
+
+func (t *HTMLRenderer) recordMiss() {
+	t.cacheMu.Lock()
+	t.cacheStats.Misses++
+	t.cacheMu.Unlock()
+}
+
+// storeEntry inserts or replaces a cacheEntry, evicting the least recently used entry if maxCacheSize is
+// exceeded
+func (t *HTMLRenderer) storeEntry(key string, entry cacheEntry) {
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+
+	if elem, ok := t.cache[key]; ok {
+		elem.Value = cacheRecord{key: key, entry: entry}
+		t.cacheOrder.MoveToFront(elem)
+		return
+	}
+
+	t.cache[key] = t.cacheOrder.PushFront(cacheRecord{key: key, entry: entry})
+
+	if t.maxCacheSize <= 0 {
+		return
+	}
+
+	for t.cacheOrder.Len() > t.maxCacheSize {
+		oldest := t.cacheOrder.Back()
+		if oldest == nil {
+			break
+		}
+
+		delete(t.cache, oldest.Value.(cacheRecord).key)
+		t.cacheOrder.Remove(oldest)
+		t.cacheStats.Evictions++
+	}
 }
 
-func (t *TemplateRenderer) GetTemplatesPath() string {
-	return t.templatesPath
+// GetFS returns the file system that templates are parsed from
+func (t *HTMLRenderer) GetFS() fs.FS {
+	return t.fsys
 }
 
-func (t *TemplateRenderer) getCacheKey(group, id string) string {
+func (t *HTMLRenderer) getCacheKey(group, id string) string {
 	return fmt.Sprintf("%s:%s", group, id)
 }
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/safehtml/template"
+	"github.com/google/safehtml/template/uncheckedconversions"
+
+	"goweb/config"
+	"goweb/funcmap"
+	"goweb/templates"
+)
+
+// safehtmlEntry stores a parsed *template.Template alongside when it was cached, so Execute can report a
+// Last-Modified value
+type safehtmlEntry struct {
+	tmpl       *template.Template
+	insertedAt time.Time
+}
+
+// SafeHTMLRenderer is a Renderer backed by github.com/google/safehtml/template, which enforces contextual
+// auto-escaping and restricts template dispatch to the *template.Template a group/id was parsed into, so a
+// name derived from user input can never execute a template outside that file set. Its cache is simpler than
+// HTMLRenderer's: file sets are cheap enough to reparse wholesale in config.EnvLocal rather than hash their
+// contents, and in production they're parsed once and never evicted.
+type SafeHTMLRenderer struct {
+	// cache stores a parsed safehtmlEntry per group/id
+	cache sync.Map
+
+	// count tracks the number of entries in cache for CacheStats(), since sync.Map has no O(1) length
+	count int64
+
+	// hits and misses accumulate CacheStats() counters, recorded once per logical Parse call
+	hits, misses int64
+
+	// funcMap stores the template function map: the same functions as HTMLRenderer's, typed as safehtml's own
+	// FuncMap
+	funcMap template.FuncMap
+
+	// trustedFS is the trusted source templates are parsed from: the embedded templates.FS in production, or
+	// a disk-backed trusted source rooted at the templates directory in config.EnvLocal
+	trustedFS template.TrustedFS
+
+	// fsys mirrors trustedFS as a plain fs.FS, so GetFS() can satisfy Renderer without exposing safehtml
+	// types outside this file
+	fsys fs.FS
+
+	// local indicates the app is running in config.EnvLocal, so sets are reparsed on every request
+	local bool
+
+	// setsMu guards sets during registration and parsing, and on every Render call (in every environment)
+	setsMu sync.Mutex
+
+	// sets stores every TemplateSpec registered via RegisterSet, keyed by name, in registration order
+	sets     map[string]TemplateSpec
+	setOrder []string
+
+	// config stores application configuration
+	config *config.Config
+}
+
+// NewSafeHTMLRenderer creates a new SafeHTMLRenderer
+func NewSafeHTMLRenderer(cfg *config.Config) *SafeHTMLRenderer {
+	t := &SafeHTMLRenderer{
+		funcMap: funcmap.GetSafeHTMLFuncMap(),
+		local:   cfg.App.Environment == config.EnvLocal,
+		config:  cfg,
+		sets:    make(map[string]TemplateSpec),
+	}
+
+	if t.local {
+		// Gets the complete templates directory path so it can be read directly off disk
+		// This is needed incase this is called from a package outside of main, such as within tests
+		//
+		// root is computed at runtime, so it can't satisfy TrustedSourceFromConstant's untyped-string-constant
+		// requirement. uncheckedconversions is safe here: root is derived from this binary's own source
+		// location, never from request input, so it meets TrustedSource's application-controlled contract
+		// even though the compiler can't verify that for us.
+		_, b, _, _ := runtime.Caller(0)
+		root := filepath.Join(filepath.Dir(b), "..", config.TemplateDir)
+		t.fsys = os.DirFS(root)
+		t.trustedFS = template.TrustedFSFromTrustedSource(uncheckedconversions.TrustedSourceFromStringKnownToSatisfyTypeContract(root))
+	} else {
+		t.fsys = templates.FS
+		t.trustedFS = template.TrustedFSFromEmbed(templates.FS)
+	}
+
+	return t
+}
+
+// Parse compiles the named template from the given files and/or directories, caching the result under
+// group/id.
+//
+// Deprecated: register a TemplateSpec with RegisterSet and render it by name with Render instead of calling
+// Parse directly per request. Parse is no longer part of Renderer; it remains exported on SafeHTMLRenderer
+// because parseSet still calls it to compile a registered set.
+func (t *SafeHTMLRenderer) Parse(group, id, name string, files []string, directories []string) error {
+	cacheKey := t.getCacheKey(group, id)
+
+	// Outside of local mode, once a set is parsed it never needs to be parsed again
+	if _, cached := t.peekEntry(cacheKey); cached && !t.local {
+		atomic.AddInt64(&t.hits, 1)
+		return nil
+	}
+	atomic.AddInt64(&t.misses, 1)
+
+	patterns := make([]string, 0, len(files)+len(directories))
+	for _, f := range files {
+		patterns = append(patterns, f+config.TemplateExt)
+	}
+	for _, dir := range directories {
+		patterns = append(patterns, fmt.Sprintf("%s/*%s", dir, config.TemplateExt))
+	}
+
+	tmpl, err := template.New(name+config.TemplateExt).Funcs(t.funcMap).ParseFS(t.trustedFS, patterns...)
+	if err != nil {
+		return err
+	}
+
+	t.storeEntry(cacheKey, safehtmlEntry{tmpl: tmpl, insertedAt: time.Now()})
+
+	return nil
+}
+
+// HasChanged reports whether group/id needs to be reparsed. Unlike HTMLRenderer, SafeHTMLRenderer doesn't
+// hash file contents to detect changes: in config.EnvLocal every check reports changed, forcing Render/Parse
+// to reparse; outside of local mode a cached entry is presumed immutable once parsed.
+func (t *SafeHTMLRenderer) HasChanged(group, id string, files []string, directories []string) (bool, error) {
+	if t.local {
+		return true, nil
+	}
+
+	_, cached := t.peekEntry(t.getCacheKey(group, id))
+
+	return !cached, nil
+}
+
+// Execute renders the group/id template into a pooled buffer and returns it alongside cache metadata for
+// conditional GETs. The caller must call ReleaseBuffer(result.Buffer) once it's done with the output.
+//
+// Deprecated: see Parse. Execute is no longer part of Renderer.
+func (t *SafeHTMLRenderer) Execute(group, id, name string, data interface{}) (*RenderResult, error) {
+	entry, ok := t.peekEntry(t.getCacheKey(group, id))
+	if !ok {
+		return nil, errors.New("uncached page template requested")
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	if err := entry.tmpl.ExecuteTemplate(buf, name+config.TemplateExt, data); err != nil {
+		ReleaseBuffer(buf)
+		return nil, err
+	}
+
+	return &RenderResult{
+		Buffer:       buf,
+		ETag:         contentETag(buf.Bytes()),
+		LastModified: entry.insertedAt,
+	}, nil
+}
+
+// ExecuteStream renders the group/id template directly to w, without allocating an intermediate buffer, and
+// returns the number of bytes written.
+func (t *SafeHTMLRenderer) ExecuteStream(group, id, name string, data interface{}, w io.Writer) (int64, error) {
+	entry, ok := t.peekEntry(t.getCacheKey(group, id))
+	if !ok {
+		return 0, errors.New("uncached page template requested")
+	}
+
+	cw := &countingWriter{w: w}
+	err := entry.tmpl.ExecuteTemplate(cw, name+config.TemplateExt, data)
+
+	return cw.n, err
+}
+
+// Load returns the *template.Template cached for group/id, boxed as any to satisfy Renderer
+func (t *SafeHTMLRenderer) Load(group, id string) (any, error) {
+	entry, ok := t.peekEntry(t.getCacheKey(group, id))
+	if !ok {
+		return nil, errors.New("uncached page template requested")
+	}
+
+	return entry.tmpl, nil
+}
+
+// RegisterSet registers a named template set described by spec. See HTMLRenderer.RegisterSet.
+func (t *SafeHTMLRenderer) RegisterSet(name string, spec TemplateSpec) {
+	t.setsMu.Lock()
+	defer t.setsMu.Unlock()
+
+	if _, exists := t.sets[name]; !exists {
+		t.setOrder = append(t.setOrder, name)
+	}
+	t.sets[name] = spec
+}
+
+// ParseRegisteredSets eagerly parses every template set registered via RegisterSet. See
+// HTMLRenderer.ParseRegisteredSets.
+func (t *SafeHTMLRenderer) ParseRegisteredSets() error {
+	t.setsMu.Lock()
+	names := append([]string(nil), t.setOrder...)
+	t.setsMu.Unlock()
+
+	var errs []error
+	for _, name := range names {
+		if err := t.parseSet(name); err != nil {
+			errs = append(errs, fmt.Errorf("template set %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Render executes the named template set registered via RegisterSet, writing the result directly to w. See
+// HTMLRenderer.Render.
+func (t *SafeHTMLRenderer) Render(w io.Writer, setName string, data interface{}) error {
+	t.setsMu.Lock()
+	spec, ok := t.sets[setName]
+	t.setsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("template set %q is not registered", setName)
+	}
+
+	if t.local {
+		if err := t.parseSet(setName); err != nil {
+			return err
+		}
+	}
+
+	entry, ok := t.peekEntry(t.getCacheKey(templateSetGroup, setName))
+	if !ok {
+		return errors.New("uncached page template requested")
+	}
+
+	return entry.tmpl.ExecuteTemplate(w, spec.execName()+config.TemplateExt, data)
+}
+
+// parseSet parses a single registered template set by name
+func (t *SafeHTMLRenderer) parseSet(name string) error {
+	t.setsMu.Lock()
+	spec, ok := t.sets[name]
+	t.setsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("template set %q is not registered", name)
+	}
+
+	files := make([]string, 0, 2)
+	if spec.Layout != "" {
+		files = append(files, spec.Layout)
+	}
+	if spec.Page != "" {
+		files = append(files, spec.Page)
+	}
+
+	return t.Parse(templateSetGroup, name, spec.execName(), files, spec.Components)
+}
+
+// CacheStats returns a snapshot of the cache's current size and cumulative hit/miss counts. SafeHTMLRenderer's
+// cache is unbounded, so MaxSize and Evictions are always zero.
+func (t *SafeHTMLRenderer) CacheStats() CacheStats {
+	return CacheStats{
+		Size:   int(atomic.LoadInt64(&t.count)),
+		Hits:   uint64(atomic.LoadInt64(&t.hits)),
+		Misses: uint64(atomic.LoadInt64(&t.misses)),
+	}
+}
+
+// Invalidate removes a single group/id from the cache, forcing it to be reparsed next time it is requested
+func (t *SafeHTMLRenderer) Invalidate(group, id string) {
+	if _, existed := t.cache.LoadAndDelete(t.getCacheKey(group, id)); existed {
+		atomic.AddInt64(&t.count, -1)
+	}
+}
+
+// InvalidateAll clears the entire cache, forcing every set to be reparsed next time it is requested
+func (t *SafeHTMLRenderer) InvalidateAll() {
+	t.cache.Range(func(key, _ any) bool {
+		t.cache.Delete(key)
+		return true
+	})
+	atomic.StoreInt64(&t.count, 0)
+}
+
+// GetFS returns the file system that templates are parsed from
+func (t *SafeHTMLRenderer) GetFS() fs.FS {
+	return t.fsys
+}
+
+func (t *SafeHTMLRenderer) peekEntry(key string) (safehtmlEntry, bool) {
+	v, ok := t.cache.Load(key)
+	if !ok {
+		return safehtmlEntry{}, false
+	}
+
+	return v.(safehtmlEntry), true
+}
+
+func (t *SafeHTMLRenderer) storeEntry(key string, entry safehtmlEntry) {
+	if _, existed := t.cache.Swap(key, entry); !existed {
+		atomic.AddInt64(&t.count, 1)
+	}
+}
+
+func (t *SafeHTMLRenderer) getCacheKey(group, id string) string {
+	return fmt.Sprintf("%s:%s", group, id)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+func TestHTMLRenderer_ExecuteStreamCountsWrittenBytes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.gohtml": {Data: []byte(`{{define "page.gohtml"}}hello{{end}}`)},
+	}
+	r := newTestHTMLRenderer(fsys, 0)
+
+	if err := r.Parse("group", "page", "page", []string{"page"}, nil); err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := r.ExecuteStream("group", "page", "page", nil, &buf)
+	if err != nil {
+		t.Fatalf("ExecuteStream returned error: %v", err)
+	}
+
+	if want := int64(buf.Len()); n != want {
+		t.Errorf("ExecuteStream returned n = %d, want %d (bytes actually written)", n, want)
+	}
+	if buf.String() != "hello" {
+		t.Errorf("ExecuteStream wrote %q, want %q", buf.String(), "hello")
+	}
+}
+
+func TestHTMLRenderer_ExecuteStreamMatchesExecuteOutput(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.gohtml": {Data: []byte(`{{define "page.gohtml"}}hello{{end}}`)},
+	}
+	r := newTestHTMLRenderer(fsys, 0)
+
+	if err := r.Parse("group", "page", "page", []string{"page"}, nil); err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	result, err := r.Execute("group", "page", "page", nil)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	defer ReleaseBuffer(result.Buffer)
+
+	var streamed bytes.Buffer
+	if _, err := r.ExecuteStream("group", "page", "page", nil, &streamed); err != nil {
+		t.Fatalf("ExecuteStream returned error: %v", err)
+	}
+
+	if streamed.String() != result.Buffer.String() {
+		t.Errorf("ExecuteStream output %q differs from Execute output %q", streamed.String(), result.Buffer.String())
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"container/list"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"goweb/config"
+	"goweb/funcmap"
+)
+
+func newTestHTMLRenderer(fsys fstest.MapFS, maxCacheSize int) *HTMLRenderer {
+	return &HTMLRenderer{
+		cache:        make(map[string]*list.Element),
+		cacheOrder:   list.New(),
+		maxCacheSize: maxCacheSize,
+		funcMap:      funcmap.GetFuncMap(),
+		fsys:         fsys,
+		sets:         make(map[string]TemplateSpec),
+		config:       &config.Config{},
+	}
+}
+
+func TestHTMLRenderer_StoreEntryEvictsLeastRecentlyUsed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.gohtml": {Data: []byte(`{{define "a.gohtml"}}a{{end}}`)},
+		"b.gohtml": {Data: []byte(`{{define "b.gohtml"}}b{{end}}`)},
+		"c.gohtml": {Data: []byte(`{{define "c.gohtml"}}c{{end}}`)},
+	}
+	r := newTestHTMLRenderer(fsys, 2)
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := r.Parse("group", id, id, []string{id}, nil); err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", id, err)
+		}
+	}
+
+	stats := r.CacheStats()
+	if stats.Size != 2 {
+		t.Errorf("CacheStats().Size = %d, want 2", stats.Size)
+	}
+	if stats.Evictions != 1 {
+		t.Errorf("CacheStats().Evictions = %d, want 1", stats.Evictions)
+	}
+	if _, ok := r.peekEntry(r.getCacheKey("group", "a")); ok {
+		t.Error("least recently used entry \"a\" was not evicted")
+	}
+	if _, ok := r.peekEntry(r.getCacheKey("group", "c")); !ok {
+		t.Error("most recently inserted entry \"c\" was evicted")
+	}
+}
+
+func TestHTMLRenderer_StoreEntryTouchingKeepsEntryAlive(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.gohtml": {Data: []byte(`{{define "a.gohtml"}}a{{end}}`)},
+		"b.gohtml": {Data: []byte(`{{define "b.gohtml"}}b{{end}}`)},
+		"c.gohtml": {Data: []byte(`{{define "c.gohtml"}}c{{end}}`)},
+	}
+	r := newTestHTMLRenderer(fsys, 2)
+
+	for _, id := range []string{"a", "b"} {
+		if err := r.Parse("group", id, id, []string{id}, nil); err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", id, err)
+		}
+	}
+
+	// Touch "a" so it's most recently used, then push "b" out instead.
+	if _, ok := r.peekEntry(r.getCacheKey("group", "a")); !ok {
+		t.Fatal("expected \"a\" to be cached")
+	}
+	if err := r.Parse("group", "c", "c", []string{"c"}, nil); err != nil {
+		t.Fatalf("Parse(\"c\") returned error: %v", err)
+	}
+
+	if _, ok := r.peekEntry(r.getCacheKey("group", "a")); !ok {
+		t.Error("recently touched entry \"a\" was evicted instead of \"b\"")
+	}
+	if _, ok := r.peekEntry(r.getCacheKey("group", "b")); ok {
+		t.Error("expected \"b\" to be evicted as the least recently used entry")
+	}
+}
+
+func TestHTMLRenderer_ParseReparsesOnContentChange(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.gohtml": {Data: []byte(`{{define "page.gohtml"}}v1{{end}}`)},
+	}
+	r := newTestHTMLRenderer(fsys, 0)
+	// Reparsing on a changed file set only happens in config.EnvLocal; outside of it, Parse treats any
+	// cached entry as permanently valid. See HTMLRenderer.Parse.
+	r.local = true
+	r.config.App.Environment = config.EnvLocal
+
+	if err := r.Parse("group", "page", "page", []string{"page"}, nil); err != nil {
+		t.Fatalf("initial Parse returned error: %v", err)
+	}
+
+	if changed, err := r.HasChanged("group", "page", []string{"page"}, nil); err != nil {
+		t.Fatalf("HasChanged returned error: %v", err)
+	} else if changed {
+		t.Error("HasChanged = true immediately after Parse, want false")
+	}
+
+	fsys["page.gohtml"] = &fstest.MapFile{Data: []byte(`{{define "page.gohtml"}}v2{{end}}`), ModTime: fsys["page.gohtml"].ModTime.Add(time.Second)}
+
+	if changed, err := r.HasChanged("group", "page", []string{"page"}, nil); err != nil {
+		t.Fatalf("HasChanged returned error: %v", err)
+	} else if !changed {
+		t.Error("HasChanged = false after content changed, want true")
+	}
+
+	if err := r.Parse("group", "page", "page", []string{"page"}, nil); err != nil {
+		t.Fatalf("reparse returned error: %v", err)
+	}
+
+	result, err := r.Execute("group", "page", "page", nil)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	defer ReleaseBuffer(result.Buffer)
+
+	if got := result.Buffer.String(); got != "v2" {
+		t.Errorf("Execute output = %q, want %q", got, "v2")
+	}
+
+	if stats := r.CacheStats(); stats.Misses != 2 {
+		t.Errorf("CacheStats().Misses = %d, want 2 (one per Parse call)", stats.Misses)
+	}
+}
+
+func TestHTMLRenderer_ExecuteETagIsStableForIdenticalOutput(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.gohtml": {Data: []byte(`{{define "page.gohtml"}}hello{{end}}`)},
+	}
+	r := newTestHTMLRenderer(fsys, 0)
+
+	if err := r.Parse("group", "page", "page", []string{"page"}, nil); err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	first, err := r.Execute("group", "page", "page", nil)
+	if err != nil {
+		t.Fatalf("first Execute returned error: %v", err)
+	}
+	firstETag := first.ETag
+	ReleaseBuffer(first.Buffer)
+
+	second, err := r.Execute("group", "page", "page", nil)
+	if err != nil {
+		t.Fatalf("second Execute returned error: %v", err)
+	}
+	defer ReleaseBuffer(second.Buffer)
+
+	if second.ETag != firstETag {
+		t.Errorf("ETag changed across identical renders: %q != %q", second.ETag, firstETag)
+	}
+}
+
+func TestReleaseBufferResetsBufferBeforeReuse(t *testing.T) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.WriteString("leftover content")
+
+	ReleaseBuffer(buf)
+
+	reused := bufferPool.Get().(*bytes.Buffer)
+	defer ReleaseBuffer(reused)
+
+	if reused.Len() != 0 {
+		t.Errorf("buffer fetched after ReleaseBuffer has Len() = %d, want 0", reused.Len())
+	}
+}
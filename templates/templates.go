@@ -0,0 +1,15 @@
+// Package templates embeds the application's template files so they can be parsed directly from the compiled
+// binary, removing the need to locate the templates directory on disk at runtime.
+package templates
+
+import "embed"
+
+// FS contains all of the embedded template files. In config.EnvLocal, the services renderers (HTMLRenderer and
+// SafeHTMLRenderer) fall back to reading these files from disk instead so that changes are picked up without
+// rebuilding the binary.
+//
+// The embed directive names each template directory explicitly rather than using "*", so this file itself
+// isn't swept up into FS alongside the actual template files.
+//
+//go:embed layouts pages components
+var FS embed.FS